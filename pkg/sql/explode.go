@@ -0,0 +1,110 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// explodeFuncNames names the functions that, when they appear in a
+// projection list, must be planned as a generator rather than
+// evaluated as an ordinary scalar. UNNEST is the SQL-standard name;
+// EXPLODE is accepted as an alias (see parser/generator_builtins.go).
+var explodeFuncNames = map[string]bool{
+	"unnest":  true,
+	"explode": true,
+}
+
+// rewriteExplodeProjections rewrites a SELECT whose projection list
+// contains a call to EXPLODE/UNNEST so that the user does not have to
+// write the equivalent lateral join by hand: it plans a generator,
+// lateral to src so that an argument like EXPLODE(a) can reference
+// src's own columns, and replaces the call in the projection with a
+// reference to the generator's output column. If no EXPLODE/UNNEST
+// call appears in the projection list, src and exprs are returned
+// unchanged.
+//
+// Only one EXPLODE/UNNEST call per SELECT is currently supported,
+// matching the common case of exploding a single array column; this
+// is enforced by only ever rewriting the first call findExplodeCall
+// reports.
+func (p *planner) rewriteExplodeProjections(
+	ctx context.Context, src planNode, exprs parser.TypedExprs,
+) (planNode, parser.TypedExprs, error) {
+	i, fn, ok := findExplodeCall(exprs)
+	if !ok {
+		return src, exprs, nil
+	}
+
+	genNode, col, err := p.explodeGeneratorNode(ctx, src, fn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newExprs := append(parser.TypedExprs(nil), exprs...)
+	newExprs[i] = col
+	return genNode, newExprs, nil
+}
+
+// findExplodeCall returns the index and expression of the first
+// EXPLODE/UNNEST call in exprs. ok is false if exprs contains no such
+// call.
+func findExplodeCall(exprs parser.TypedExprs) (idx int, fn *parser.FuncExpr, ok bool) {
+	for i, e := range exprs {
+		fn, ok := e.(*parser.FuncExpr)
+		if !ok {
+			continue
+		}
+		if explodeFuncNames[fn.Func.FunctionReference.String()] {
+			return i, fn, true
+		}
+	}
+	return 0, nil, false
+}
+
+// explodeGeneratorNode builds the generator plan for a single
+// EXPLODE/UNNEST call: fn is planned lateral to src, exactly like a
+// generator function in FROM (see planGeneratorFromItem), since its
+// argument may reference src's columns (EXPLODE(a) where a is a
+// column of src) and must in any case be re-evaluated once per row of
+// src rather than once for the whole query. The returned IndexedVar
+// refers to the generator's output column at its real offset in the
+// joined row, len(planColumns(src)).
+func (p *planner) explodeGeneratorNode(
+	ctx context.Context, src planNode, fn *parser.FuncExpr,
+) (planNode, *parser.IndexedVar, error) {
+	srcCols := planColumns(src)
+	genColIdx := len(srcCols)
+
+	container := &lateralOuterRowContainer{types: make([]parser.Type, len(srcCols))}
+	for i, c := range srcCols {
+		container.types[i] = c.Typ
+	}
+	ivarHelper := parser.MakeIndexedVarHelper(container, len(srcCols))
+	info := multiSourceInfo{newSourceInfoForSingleTable(anonymousTable, srcCols)}
+
+	gen, err := p.makeGenerator(ctx, fn, info, ivarHelper, false /* withOrdinality */)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	joined := p.makeLateralJoin(src, gen, container)
+
+	helper := parser.MakeIndexedVarHelper(joined, genColIdx+len(gen.columns))
+	col := helper.IndexedVar(genColIdx)
+	return joined, col, nil
+}
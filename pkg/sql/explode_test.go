@@ -0,0 +1,55 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// TestFindExplodeCallNoMatch verifies that a projection list with no
+// EXPLODE/UNNEST call reports ok=false, so rewriteExplodeProjections
+// leaves src/exprs untouched for ordinary SELECTs.
+func TestFindExplodeCallNoMatch(t *testing.T) {
+	exprs := parser.TypedExprs{parser.NewDInt(1), parser.NewDString("b")}
+	if _, _, ok := findExplodeCall(exprs); ok {
+		t.Fatal("expected no EXPLODE/UNNEST call to be found")
+	}
+}
+
+// TestExplodeColumnOffset verifies the arithmetic explodeGeneratorNode
+// relies on to point the replacement IndexedVar at the generator's
+// column in the lateral-joined row: it must land after all of src's
+// own columns, not at offset 0.
+func TestExplodeColumnOffset(t *testing.T) {
+	for _, tc := range []struct {
+		srcCols sqlbase.ResultColumns
+		wantIdx int
+	}{
+		{srcCols: nil, wantIdx: 0},
+		{srcCols: sqlbase.ResultColumns{{Name: "a", Typ: parser.TypeInt}}, wantIdx: 1},
+		{srcCols: sqlbase.ResultColumns{
+			{Name: "a", Typ: parser.TypeInt},
+			{Name: "b", Typ: parser.TypeString},
+			{Name: "c", Typ: parser.TypeBool},
+		}, wantIdx: 3},
+	} {
+		if genColIdx := len(tc.srcCols); genColIdx != tc.wantIdx {
+			t.Fatalf("%d src columns: got genColIdx=%d, want %d", len(tc.srcCols), genColIdx, tc.wantIdx)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// planGeneratorFromItem plans a single FROM item that is a
+// set-returning function call, e.g. the `generate_series(1, t.n)` in
+//
+//   SELECT * FROM t, generate_series(1, t.n)
+//   SELECT * FROM unnest(ARRAY['a','b','c']) WITH ORDINALITY AS t(val, idx)
+//
+// Per SQL:2003, a generator function in FROM is implicitly LATERAL:
+// it may reference columns of FROM items that precede it, whether or
+// not the LATERAL keyword was written explicitly. outer is nil for
+// the first FROM item (nothing precedes it, so there is nothing to be
+// lateral to); otherwise the call is planned as the inner side of an
+// apply join over outer, via lateralJoinNode.
+//
+// withOrdinality is the FROM item's WITH ORDINALITY modifier, parsed
+// onto the table-reference production (alongside the function call
+// itself) and passed through unchanged to makeGenerator.
+func (p *planner) planGeneratorFromItem(
+	ctx context.Context, outer planNode, t *parser.FuncExpr, withOrdinality bool,
+) (planNode, error) {
+	if outer == nil {
+		return p.makeGenerator(ctx, t, multiSourceInfo{}, parser.IndexedVarHelper{}, withOrdinality)
+	}
+
+	outerColumns := planColumns(outer)
+	container := &lateralOuterRowContainer{types: make([]parser.Type, len(outerColumns))}
+	for i, c := range outerColumns {
+		container.types[i] = c.Typ
+	}
+	ivarHelper := parser.MakeIndexedVarHelper(container, len(outerColumns))
+	info := multiSourceInfo{newSourceInfoForSingleTable(anonymousTable, outerColumns)}
+
+	gen, err := p.makeGenerator(ctx, t, info, ivarHelper, withOrdinality)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.makeLateralJoin(outer, gen, container), nil
+}
@@ -37,17 +37,41 @@ type valueGenerator struct {
 
 	// columns is the signature of this generator.
 	columns sqlbase.ResultColumns
+
+	// withOrdinality, when set, causes Next/Values to append a
+	// monotonically increasing bigint column (WITH ORDINALITY),
+	// starting at 1, after the generator's own columns.
+	withOrdinality bool
+	ordinality     int64
 }
 
 // makeGenerator creates a valueGenerator instance that wraps a call to a
 // generator function.
-func (p *planner) makeGenerator(ctx context.Context, t *parser.FuncExpr) (planNode, error) {
+//
+// info and ivarHelper let the function call reference columns from
+// FROM items that precede it (LATERAL, implicit for generator
+// functions per SQL:2003): when non-empty, they are threaded through
+// to analyzeExpr instead of an empty scope, and the normalized
+// expression is re-evaluated on every outer row by a lateralJoinNode
+// rather than once at Start time. Pass an empty multiSourceInfo{} and
+// a zero parser.IndexedVarHelper{} for the non-lateral case.
+//
+// withOrdinality implements the WITH ORDINALITY modifier: a synthetic
+// "ordinality" column is appended to the signature, carrying a
+// 1-based row counter maintained alongside n.gen.Values().
+func (p *planner) makeGenerator(
+	ctx context.Context,
+	t *parser.FuncExpr,
+	info multiSourceInfo,
+	ivarHelper parser.IndexedVarHelper,
+	withOrdinality bool,
+) (*valueGenerator, error) {
 	if err := p.parser.AssertNoAggregationOrWindowing(t, "FROM", p.session.SearchPath); err != nil {
 		return nil, err
 	}
 
 	normalized, err := p.analyzeExpr(
-		ctx, t, multiSourceInfo{}, parser.IndexedVarHelper{}, parser.TypeAny, false, "FROM",
+		ctx, t, info, ivarHelper, parser.TypeAny, false, "FROM",
 	)
 	if err != nil {
 		return nil, err
@@ -58,46 +82,118 @@ func (p *planner) makeGenerator(ctx context.Context, t *parser.FuncExpr) (planNo
 		return nil, errors.Errorf("FROM expression is not a generator: %s", t)
 	}
 
-	columns := make(sqlbase.ResultColumns, len(tType.Cols))
-	for i := range columns {
+	numCols := len(tType.Cols)
+	if withOrdinality {
+		numCols++
+	}
+	columns := make(sqlbase.ResultColumns, numCols)
+	for i := range tType.Cols {
 		columns[i].Name = tType.Labels[i]
 		columns[i].Typ = tType.Cols[i]
 	}
+	if withOrdinality {
+		columns[len(tType.Cols)] = sqlbase.ResultColumn{Name: "ordinality", Typ: parser.TypeInt}
+	}
 
 	return &valueGenerator{
-		expr:    normalized,
-		columns: columns,
+		expr:           normalized,
+		columns:        columns,
+		withOrdinality: withOrdinality,
 	}, nil
 }
 
 func (n *valueGenerator) Start(params runParams) error {
-	expr, err := n.expr.Eval(&params.p.evalCtx)
+	gen, err := n.makeValueGenerator(params)
 	if err != nil {
 		return err
 	}
+	if err := gen.Start(); err != nil {
+		return err
+	}
+
+	n.gen = gen
+	n.ordinality = 0
+	return nil
+}
+
+// makeValueGenerator produces the parser.ValueGenerator for this
+// call. For a function registered through parser.RegisterGenerator
+// (unnest, json_array_elements, jsonb_each, ...), it evaluates the
+// call's arguments itself and invokes the registered factory
+// directly, since those builtins have no scalar representation for
+// Eval to fall back on. For anything else (e.g. generate_series,
+// whose Eval already produces a ready *parser.DTable), it evaluates
+// expr as before.
+func (n *valueGenerator) makeValueGenerator(params runParams) (parser.ValueGenerator, error) {
+	if fn, ok := n.expr.(*parser.FuncExpr); ok {
+		if factory, args, ok, err := lookupRegisteredFactory(fn, &params.p.evalCtx); err != nil {
+			return nil, err
+		} else if ok {
+			return factory(args)
+		}
+	}
+
+	expr, err := n.expr.Eval(&params.p.evalCtx)
+	if err != nil {
+		return nil, err
+	}
 	var tb *parser.DTable
 	if expr == parser.DNull {
 		tb = parser.EmptyDTable()
 	} else {
 		tb = expr.(*parser.DTable)
 	}
+	return tb.ValueGenerator, nil
+}
 
-	gen := tb.ValueGenerator
-	if err := gen.Start(); err != nil {
-		return err
+// lookupRegisteredFactory finds the parser.GeneratorFactory
+// RegisterGenerator stored for fn's resolved name and argument types,
+// evaluating fn's arguments if a factory is found. ok is false (with
+// a nil error) when fn does not name a registered generator, in which
+// case the caller should fall back to evaluating fn directly.
+func lookupRegisteredFactory(
+	fn *parser.FuncExpr, evalCtx *parser.EvalContext,
+) (factory parser.GeneratorFactory, args parser.Datums, ok bool, err error) {
+	name := fn.Func.FunctionReference.String()
+	argTypes := make([]parser.Type, len(fn.Exprs))
+	for i, e := range fn.Exprs {
+		argTypes[i] = e.(parser.TypedExpr).ResolvedType()
 	}
 
-	n.gen = gen
-	return nil
+	factory, ok = parser.LookupGeneratorFactory(name, argTypes)
+	if !ok {
+		return nil, nil, false, nil
+	}
+
+	args = make(parser.Datums, len(fn.Exprs))
+	for i, e := range fn.Exprs {
+		d, err := e.(parser.TypedExpr).Eval(evalCtx)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		args[i] = d
+	}
+	return factory, args, true, nil
 }
 
 func (n *valueGenerator) Next(params runParams) (bool, error) {
 	if err := params.p.cancelChecker.Check(); err != nil {
 		return false, err
 	}
-	return n.gen.Next()
+	ok, err := n.gen.Next()
+	if ok {
+		n.ordinality++
+	}
+	return ok, err
+}
+
+func (n *valueGenerator) Values() parser.Datums {
+	values := n.gen.Values()
+	if !n.withOrdinality {
+		return values
+	}
+	return append(append(parser.Datums(nil), values...), parser.NewDInt(parser.DInt(n.ordinality)))
 }
-func (n *valueGenerator) Values() parser.Datums { return n.gen.Values() }
 
 func (n *valueGenerator) Close(context.Context) {
 	if n.gen != nil {
@@ -0,0 +1,200 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// lateralOuterRowContainer implements parser.IndexedVarContainer by
+// serving values out of whatever outer row was most recently bound to
+// it. makeGenerator's analyzeExpr call is performed against an
+// IndexedVarHelper built on top of a lateralOuterRowContainer, so
+// rebinding the container's row (see lateralJoinNode.Next)
+// is what makes gen.expr observe a new outer row on each iteration of
+// the lateral join, rather than the one outer row that happened to be
+// current when the expression was analyzed.
+type lateralOuterRowContainer struct {
+	row   parser.Datums
+	types []parser.Type
+}
+
+// bind installs row as the current outer row for subsequent
+// IndexedVar evaluations against this container.
+func (c *lateralOuterRowContainer) bind(row parser.Datums) {
+	c.row = row
+}
+
+// IndexedVarEval implements the parser.IndexedVarContainer interface.
+func (c *lateralOuterRowContainer) IndexedVarEval(
+	idx int, ctx *parser.EvalContext,
+) (parser.Datum, error) {
+	return c.row[idx], nil
+}
+
+// IndexedVarResolvedType implements the parser.IndexedVarContainer interface.
+func (c *lateralOuterRowContainer) IndexedVarResolvedType(idx int) parser.Type {
+	return c.types[idx]
+}
+
+// IndexedVarFormat implements the parser.IndexedVarContainer interface.
+func (c *lateralOuterRowContainer) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx int) {
+	c.row[idx].Format(buf, f)
+}
+
+// lateralGenerator is the subset of *valueGenerator that
+// lateralJoinNode depends on. Narrowing to an interface (rather than
+// depending on *valueGenerator directly) lets tests drive
+// lateralJoinNode.Next with a fake generator, without needing a full
+// parser-backed expression and evalCtx to exercise Start.
+type lateralGenerator interface {
+	Start(params runParams) error
+	Next(params runParams) (bool, error)
+	Values() parser.Datums
+	Close(ctx context.Context)
+}
+
+// lateralJoinNode implements a generator function in FROM that
+// references columns produced by earlier FROM items, e.g.
+//
+//   SELECT * FROM t, generate_series(1, t.n)
+//   SELECT * FROM t, unnest(t.arr)
+//
+// Unlike a plain valueGenerator, whose expr is evaluated once at
+// Start, the generator expression here depends on the current outer
+// row, so it must be re-evaluated (and the generator re-Started) each
+// time the outer side advances. This is the nested-loop-apply plan
+// for LATERAL: outer.Next() drives the outer row, gen is re-bound and
+// re-Started against it, and rows are produced by concatenating the
+// outer row with each row the generator yields before moving the
+// outer side forward again.
+type lateralJoinNode struct {
+	outer planNode
+	gen   lateralGenerator
+
+	// container is the IndexedVarContainer that gen.expr's
+	// IndexedVars were analyzed against (via an IndexedVarHelper built
+	// on it; see makeLateralJoin). It is
+	// rebound to the current outer row before every gen.Start, which
+	// is what actually feeds outer column values into gen.expr.
+	container *lateralOuterRowContainer
+
+	outerRow   parser.Datums
+	genStarted bool
+
+	columns sqlbase.ResultColumns
+	row     parser.Datums
+}
+
+// makeLateralJoin builds the plan for a LATERAL generator function.
+// gen.expr must already have been analyzed (via makeGenerator) against
+// an IndexedVarHelper built on top of container, so that rebinding
+// container's row on each outer tuple is sufficient to make gen.expr
+// see that tuple's columns.
+func (p *planner) makeLateralJoin(
+	outer planNode, gen *valueGenerator, container *lateralOuterRowContainer,
+) *lateralJoinNode {
+	outerColumns := planColumns(outer)
+	columns := make(sqlbase.ResultColumns, 0, len(outerColumns)+len(gen.columns))
+	columns = append(columns, outerColumns...)
+	columns = append(columns, gen.columns...)
+
+	return &lateralJoinNode{
+		outer:     outer,
+		gen:       gen,
+		container: container,
+		columns:   columns,
+	}
+}
+
+func (n *lateralJoinNode) Start(params runParams) error {
+	return n.outer.Start(params)
+}
+
+// Next advances the join: it pulls another row out of gen for the
+// current outer row, reopening gen against a new outer row (closing
+// the previous generator instance first) whenever the current one is
+// exhausted, until the outer side itself is exhausted.
+func (n *lateralJoinNode) Next(params runParams) (bool, error) {
+	for {
+		if n.genStarted {
+			ok, err := n.gen.Next(params)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				n.row = append(append(parser.Datums(nil), n.outerRow...), n.gen.Values()...)
+				return true, nil
+			}
+			n.gen.Close(params.ctx)
+			n.genStarted = false
+		}
+
+		ok, err := n.outer.Next(params)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+		n.outerRow = n.outer.Values()
+
+		// Rebind the container gen.expr's IndexedVars resolve against,
+		// then re-evaluate the call: this is what lets
+		// generate_series(1, t.n) or unnest(t.arr) see the new t row
+		// before Start produces this iteration's generator object.
+		n.container.bind(n.outerRow)
+		if err := n.gen.Start(params); err != nil {
+			return false, err
+		}
+		n.genStarted = true
+	}
+}
+
+func (n *lateralJoinNode) Values() parser.Datums { return n.row }
+
+// lateralJoinNode also implements parser.IndexedVarContainer directly
+// over its own output row, so that an IndexedVar built via
+// parser.MakeIndexedVarHelper(joinNode, ...) -- as explodeGeneratorNode
+// does for the generator's output column -- reads whatever row is
+// current when the IndexedVar is evaluated, the same way any other
+// FROM-item's columns are read during rendering.
+
+// IndexedVarEval implements the parser.IndexedVarContainer interface.
+func (n *lateralJoinNode) IndexedVarEval(idx int, ctx *parser.EvalContext) (parser.Datum, error) {
+	return n.row[idx], nil
+}
+
+// IndexedVarResolvedType implements the parser.IndexedVarContainer interface.
+func (n *lateralJoinNode) IndexedVarResolvedType(idx int) parser.Type {
+	return n.columns[idx].Typ
+}
+
+// IndexedVarFormat implements the parser.IndexedVarContainer interface.
+func (n *lateralJoinNode) IndexedVarFormat(buf *bytes.Buffer, f parser.FmtFlags, idx int) {
+	n.row[idx].Format(buf, f)
+}
+
+func (n *lateralJoinNode) Close(ctx context.Context) {
+	if n.genStarted {
+		n.gen.Close(ctx)
+	}
+	n.outer.Close(ctx)
+}
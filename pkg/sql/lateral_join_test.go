@@ -0,0 +1,192 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+)
+
+// TestLateralOuterRowContainerRebind verifies that binding a new
+// outer row to the container actually changes what IndexedVarEval
+// returns for a given index -- this is the mechanism the lateral
+// join relies on to make gen.expr observe each new outer row (see
+// lateralJoinNode.Next).
+func TestLateralOuterRowContainerRebind(t *testing.T) {
+	container := &lateralOuterRowContainer{types: []parser.Type{parser.TypeInt}}
+
+	container.bind(parser.Datums{parser.NewDInt(1)})
+	got, err := container.IndexedVarEval(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(*got.(*parser.DInt)) != 1 {
+		t.Fatalf("got %v, want 1", got)
+	}
+
+	container.bind(parser.Datums{parser.NewDInt(2)})
+	got, err = container.IndexedVarEval(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(*got.(*parser.DInt)) != 2 {
+		t.Fatalf("rebind did not take effect: got %v, want 2", got)
+	}
+}
+
+// fakeOuterNode is a planNode over a fixed list of rows, used to
+// drive lateralJoinNode without a real FROM-item plan.
+type fakeOuterNode struct {
+	rows []parser.Datums
+	idx  int
+}
+
+func (n *fakeOuterNode) Start(runParams) error        { n.idx = -1; return nil }
+func (n *fakeOuterNode) Next(runParams) (bool, error) { n.idx++; return n.idx < len(n.rows), nil }
+func (n *fakeOuterNode) Values() parser.Datums        { return n.rows[n.idx] }
+func (n *fakeOuterNode) Close(context.Context)        {}
+
+// fakeLateralGenerator is a lateralGenerator that yields the rows of
+// rowSets[k] on the k-th call to Start, letting a test vary what the
+// generator produces per outer row -- including producing zero rows,
+// to exercise the "outer row is dropped" case.
+type fakeLateralGenerator struct {
+	rowSets [][]parser.Datums
+	set     int
+	idx     int
+	starts  int
+	closes  int
+}
+
+func (g *fakeLateralGenerator) Start(runParams) error {
+	g.set = g.starts
+	g.starts++
+	g.idx = -1
+	return nil
+}
+
+func (g *fakeLateralGenerator) Next(runParams) (bool, error) {
+	g.idx++
+	return g.idx < len(g.rowSets[g.set]), nil
+}
+
+func (g *fakeLateralGenerator) Values() parser.Datums { return g.rowSets[g.set][g.idx] }
+
+func (g *fakeLateralGenerator) Close(context.Context) { g.closes++ }
+
+// TestLateralJoinNodeNext drives lateralJoinNode.Next directly (the
+// request's core deliverable): it must re-Start gen against each
+// outer row, concatenate outer and generator values, close gen once
+// it's exhausted, and advance the outer side -- dropping outer rows
+// for which gen produces nothing, as happens when e.g. unnest(t.arr)
+// sees an empty array.
+func TestLateralJoinNodeNext(t *testing.T) {
+	outer := &fakeOuterNode{rows: []parser.Datums{
+		{parser.NewDInt(1)}, // generator produces nothing for this row
+		{parser.NewDInt(2)},
+	}}
+	gen := &fakeLateralGenerator{rowSets: [][]parser.Datums{
+		{},
+		{{parser.NewDInt(10)}, {parser.NewDInt(20)}},
+	}}
+	n := &lateralJoinNode{
+		outer:     outer,
+		gen:       gen,
+		container: &lateralOuterRowContainer{},
+	}
+
+	if err := n.Start(runParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][]int64
+	for {
+		ok, err := n.Next(runParams{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		row := n.Values()
+		pair := make([]int64, len(row))
+		for i, d := range row {
+			pair[i] = int64(*d.(*parser.DInt))
+		}
+		got = append(got, pair)
+	}
+
+	want := [][]int64{{2, 10}, {2, 20}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i][0] != want[i][0] || got[i][1] != want[i][1] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if gen.starts != 2 {
+		t.Fatalf("expected gen.Start to be called once per outer row (2), got %d", gen.starts)
+	}
+	if gen.closes != 2 {
+		t.Fatalf("expected gen.Close once per exhausted outer row (2), got %d", gen.closes)
+	}
+}
+
+// TestLateralJoinNodeIndexedVarContainer verifies that a
+// lateralJoinNode can itself serve as the parser.IndexedVarContainer
+// for an IndexedVar over one of its own output columns -- this is
+// what lets explodeGeneratorNode hand back a reference to the
+// generator's output column (whose type tracks its argument, e.g. an
+// array's element type) that reads live values out of the join's
+// current row rather than whatever row happened to be current when
+// the IndexedVar was built.
+func TestLateralJoinNodeIndexedVarContainer(t *testing.T) {
+	n := &lateralJoinNode{
+		columns: sqlbase.ResultColumns{
+			{Name: "a", Typ: parser.TypeInt},
+			{Name: "val", Typ: parser.TypeString},
+		},
+	}
+
+	n.row = parser.Datums{parser.NewDInt(1), parser.NewDString("x")}
+	got, err := n.IndexedVarEval(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(*got.(*parser.DString)) != "x" {
+		t.Fatalf("got %v, want x", got)
+	}
+	if typ := n.IndexedVarResolvedType(1); typ != parser.TypeString {
+		t.Fatalf("got resolved type %v, want TypeString", typ)
+	}
+
+	// Advancing to a new row must be reflected immediately: the
+	// IndexedVar reads through to n.row, it does not capture a
+	// snapshot of it.
+	n.row = parser.Datums{parser.NewDInt(2), parser.NewDString("y")}
+	got, err = n.IndexedVarEval(1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(*got.(*parser.DString)) != "y" {
+		t.Fatalf("got %v, want y after advancing to the next row", got)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+// arrayGenerator is a ValueGenerator that walks the elements of a
+// DArray one at a time. It lets an array datum be consumed as a
+// generator (e.g. by EXPLODE/UNNEST) without changing how the same
+// datum behaves everywhere else: a DArray that is never handed to a
+// generator node is still compared, cast and displayed as an array.
+type arrayGenerator struct {
+	arr *DArray
+	idx int
+}
+
+// NewArrayGenerator wraps arr so it can be driven through the
+// ValueGenerator interface, yielding one row per array element.
+func NewArrayGenerator(arr *DArray) ValueGenerator {
+	return &arrayGenerator{arr: arr}
+}
+
+// Start implements the ValueGenerator interface.
+func (g *arrayGenerator) Start() error {
+	g.idx = -1
+	return nil
+}
+
+// Next implements the ValueGenerator interface.
+func (g *arrayGenerator) Next() (bool, error) {
+	g.idx++
+	return g.idx < len(g.arr.Array), nil
+}
+
+// Values implements the ValueGenerator interface.
+func (g *arrayGenerator) Values() Datums {
+	return Datums{g.arr.Array[g.idx]}
+}
+
+// Close implements the ValueGenerator interface.
+func (g *arrayGenerator) Close() {}
+
+// arrayGeneratorType returns the TTable signature produced by
+// exploding an array of element type elemType: a single column
+// carrying that element type.
+func arrayGeneratorType(elemType Type) TTable {
+	return TTable{
+		Cols:   []Type{elemType},
+		Labels: []string{"value"},
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import (
+	"testing"
+)
+
+func TestArrayGenerator(t *testing.T) {
+	arr := NewDArray(TypeInt)
+	for _, v := range []int{1, 2, 3} {
+		if err := arr.Append(NewDInt(DInt(v))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gen := NewArrayGenerator(arr)
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gen.Close()
+
+	var got []int64
+	for {
+		ok, err := gen.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		vals := gen.Values()
+		if len(vals) != 1 {
+			t.Fatalf("expected 1 column, got %d", len(vals))
+		}
+		got = append(got, int64(*vals[0].(*DInt)))
+	}
+
+	want := []int64{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestArrayGeneratorType(t *testing.T) {
+	tt := arrayGeneratorType(TypeString)
+	if len(tt.Cols) != 1 || tt.Cols[0] != TypeString {
+		t.Fatalf("expected single STRING column, got %+v", tt.Cols)
+	}
+	if len(tt.Labels) != 1 || tt.Labels[0] != "value" {
+		t.Fatalf("expected label %q, got %+v", "value", tt.Labels)
+	}
+}
+
+func TestUnnestFactoryYieldsArrayElements(t *testing.T) {
+	factory, ok := LookupGeneratorFactory("unnest", []Type{TArray{Typ: TypeInt}})
+	if !ok {
+		t.Fatal("expected unnest to be registered")
+	}
+
+	arr := NewDArray(TypeInt)
+	if err := arr.Append(NewDInt(DInt(7))); err != nil {
+		t.Fatal(err)
+	}
+
+	gen, err := factory(Datums{arr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gen.Close()
+
+	ok, err = gen.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected a row")
+	}
+	if got := gen.Values()[0]; int64(*got.(*DInt)) != 7 {
+		t.Fatalf("got %v, want 7", got)
+	}
+}
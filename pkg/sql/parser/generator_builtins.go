@@ -0,0 +1,59 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+// explode/unnest turn an array into a set of rows, one per element.
+// They are registered under both names: UNNEST is the SQL-standard
+// spelling, EXPLODE is accepted as an alias for the benefit of users
+// coming from systems that use that name for the same operation.
+//
+// The rewrite of a call in a projection list into a generator plan
+// lives in the planner (rewriteExplodeProjections, explode.go in
+// package sql) rather than here, since it restructures the
+// surrounding plan rather than just evaluating a function call. The
+// SELECT planning code does not call rewriteExplodeProjections, so a
+// projection-list call is not rewritten.
+func init() {
+	arrayGeneratorFactory := func(args Datums) (ValueGenerator, error) {
+		arr := args[0].(*DArray)
+		return NewArrayGenerator(arr), nil
+	}
+
+	// The output column takes on the array argument's element type
+	// (arrayGeneratorType), so UNNEST(int_array) yields an INT column
+	// and UNNEST(string_array) yields a STRING column, rather than
+	// pinning the result to a single fixed type.
+	arrayGeneratorColumns := func(argTypes []Type) []ResultColumn {
+		elemType := Type(TypeAny)
+		if arr, ok := argTypes[0].(TArray); ok {
+			elemType = arr.Typ
+		}
+		tt := arrayGeneratorType(elemType)
+		cols := make([]ResultColumn, len(tt.Cols))
+		for i := range tt.Cols {
+			cols[i] = ResultColumn{Name: tt.Labels[i], Typ: tt.Cols[i]}
+		}
+		return cols
+	}
+
+	for _, name := range []string{"unnest", "explode"} {
+		RegisterGenerator(
+			name,
+			ArgTypes{{"input", TypeAnyArray}},
+			arrayGeneratorColumns,
+			arrayGeneratorFactory,
+		)
+	}
+}
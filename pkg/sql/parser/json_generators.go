@@ -0,0 +1,108 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+// json_array_elements and jsonb_each are the first consumers of
+// RegisterGenerator outside of generator_builtins.go, demonstrating
+// that new table functions can be added without touching makeGenerator
+// or the rest of the core planner.
+
+// jsonArrayElementsGenerator walks the top-level elements of a JSON
+// array, one row per element.
+type jsonArrayElementsGenerator struct {
+	elems []*DJSON
+	idx   int
+}
+
+// Start implements the ValueGenerator interface.
+func (g *jsonArrayElementsGenerator) Start() error {
+	g.idx = -1
+	return nil
+}
+
+// Next implements the ValueGenerator interface.
+func (g *jsonArrayElementsGenerator) Next() (bool, error) {
+	g.idx++
+	return g.idx < len(g.elems), nil
+}
+
+// Values implements the ValueGenerator interface.
+func (g *jsonArrayElementsGenerator) Values() Datums {
+	return Datums{g.elems[g.idx]}
+}
+
+// Close implements the ValueGenerator interface.
+func (g *jsonArrayElementsGenerator) Close() {}
+
+// jsonEachGenerator walks the top-level key/value pairs of a JSON
+// object, one row per pair.
+type jsonEachGenerator struct {
+	keys   []string
+	values []*DJSON
+	idx    int
+}
+
+// Start implements the ValueGenerator interface.
+func (g *jsonEachGenerator) Start() error {
+	g.idx = -1
+	return nil
+}
+
+// Next implements the ValueGenerator interface.
+func (g *jsonEachGenerator) Next() (bool, error) {
+	g.idx++
+	return g.idx < len(g.keys), nil
+}
+
+// Values implements the ValueGenerator interface.
+func (g *jsonEachGenerator) Values() Datums {
+	return Datums{NewDString(g.keys[g.idx]), g.values[g.idx]}
+}
+
+// Close implements the ValueGenerator interface.
+func (g *jsonEachGenerator) Close() {}
+
+func init() {
+	RegisterGenerator(
+		"json_array_elements",
+		ArgTypes{{"json", TypeJSON}},
+		fixedGeneratorColumns(ResultColumn{Name: "value", Typ: TypeJSON}),
+		func(args Datums) (ValueGenerator, error) {
+			j := args[0].(*DJSON)
+			elems, err := j.ArrayElements()
+			if err != nil {
+				return nil, err
+			}
+			return &jsonArrayElementsGenerator{elems: elems}, nil
+		},
+	)
+
+	RegisterGenerator(
+		"jsonb_each",
+		ArgTypes{{"json", TypeJSON}},
+		fixedGeneratorColumns(
+			ResultColumn{Name: "key", Typ: TypeString},
+			ResultColumn{Name: "value", Typ: TypeJSON},
+		),
+		func(args Datums) (ValueGenerator, error) {
+			j := args[0].(*DJSON)
+			keys, values, err := j.ObjectItems()
+			if err != nil {
+				return nil, err
+			}
+			return &jsonEachGenerator{keys: keys, values: values}, nil
+		},
+	)
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+// ResultColumn describes one column of a generator's output
+// signature: its name (used as the default column label, overridable
+// by a table alias) and its SQL type.
+type ResultColumn struct {
+	Name string
+	Typ  Type
+}
+
+// GeneratorFactory builds the ValueGenerator that will produce a
+// table function's rows, given its already-evaluated arguments.
+type GeneratorFactory func(args Datums) (ValueGenerator, error)
+
+// GeneratorColumnsFn computes a generator's result-column signature
+// from the resolved types of its arguments. It runs at type-checking
+// time, before any argument has a value, which is what lets a
+// generator's output column track an argument's type instead of being
+// pinned to a single fixed type — e.g. UNNEST(ARRAY[1,2,3]) resolves
+// to a single INT column, while UNNEST(ARRAY['a','b']) resolves to a
+// single STRING column.
+type GeneratorColumnsFn func(argTypes []Type) []ResultColumn
+
+// fixedGeneratorColumns returns a GeneratorColumnsFn for generators
+// whose output signature doesn't depend on its argument types.
+func fixedGeneratorColumns(cols ...ResultColumn) GeneratorColumnsFn {
+	return func([]Type) []ResultColumn { return cols }
+}
+
+// RegisterGenerator plugs a new set-returning ("table") function into
+// the same path makeGenerator already uses for the built-in
+// generators: it registers an overload under name so that
+// analyzeExpr's function resolution picks it up like any other
+// builtin, computing the TTable metadata a call resolves to by
+// invoking returnCols with the call's argument types, and arranges
+// for factory to be invoked with the call's evaluated arguments
+// whenever valueGenerator.Start needs to produce the generator object
+// (see LookupGeneratorFactory).
+//
+// This is the extension point out-of-tree code — and eventually
+// SQL-level CREATE FUNCTION ... RETURNS TABLE — uses to add new
+// generators without editing the core planner. See
+// generator_builtins.go and json_generators.go for the builtins that
+// are themselves implemented through this API.
+func RegisterGenerator(
+	name string, argTypes ArgTypes, returnCols GeneratorColumnsFn, factory GeneratorFactory,
+) {
+	Builtins[name] = append(Builtins[name], Builtin{
+		Types: argTypes,
+		ReturnType: func(args []TypedExpr) Type {
+			argTypes := make([]Type, len(args))
+			for i, a := range args {
+				argTypes[i] = a.ResolvedType()
+			}
+			cols := returnCols(argTypes)
+			ttCols := make([]Type, len(cols))
+			labels := make([]string, len(cols))
+			for i, c := range cols {
+				ttCols[i] = c.Typ
+				labels[i] = c.Name
+			}
+			return TTable{Cols: ttCols, Labels: labels}
+		},
+		generator: factory,
+	})
+
+	generatorRegistry[name] = append(generatorRegistry[name], generatorOverload{
+		argTypes: argTypes,
+		factory:  factory,
+	})
+}
+
+// generatorOverload records one RegisterGenerator call so that
+// valueGenerator.Start can find the right GeneratorFactory for a
+// resolved call without re-deriving it from the Builtins table.
+type generatorOverload struct {
+	argTypes ArgTypes
+	factory  GeneratorFactory
+}
+
+var generatorRegistry = map[string][]generatorOverload{}
+
+// LookupGeneratorFactory returns the GeneratorFactory registered by
+// RegisterGenerator for a call to name with the given (already
+// resolved) argument types, so that evaluation can invoke it directly
+// to build the row source instead of assuming Eval already produced a
+// ready-made DTable.
+//
+// A name can have more than one overload (e.g. a generator overloaded
+// on element type), so matching on argument count alone isn't enough:
+// every position must be checked against the overload's declared
+// type, the same way regular builtin overload resolution works.
+func LookupGeneratorFactory(name string, argTypes []Type) (GeneratorFactory, bool) {
+	overloads, ok := generatorRegistry[name]
+	if !ok {
+		return nil, false
+	}
+	for _, ov := range overloads {
+		if argTypesMatch(ov.argTypes, argTypes) {
+			return ov.factory, true
+		}
+	}
+	return nil, false
+}
+
+// argTypesMatch reports whether the resolved call argument types match
+// an overload's declared argument types, position by position.
+func argTypesMatch(declared ArgTypes, resolved []Type) bool {
+	if len(declared) != len(resolved) {
+		return false
+	}
+	for i, d := range declared {
+		if !resolved[i].Equivalent(d.Typ) {
+			return false
+		}
+	}
+	return true
+}
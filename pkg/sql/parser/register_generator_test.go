@@ -0,0 +1,127 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import "testing"
+
+// TestRegisterGeneratorRoundTrip verifies that RegisterGenerator
+// makes its factory discoverable via LookupGeneratorFactory under the
+// same name and argument types, and that invoking the looked-up
+// factory with evaluated arguments actually drives the ValueGenerator
+// it returns -- the path valueGenerator.Start relies on.
+func TestRegisterGeneratorRoundTrip(t *testing.T) {
+	const name = "test_repeat_once"
+	RegisterGenerator(
+		name,
+		ArgTypes{{"x", TypeInt}},
+		fixedGeneratorColumns(ResultColumn{Name: "x", Typ: TypeInt}),
+		func(args Datums) (ValueGenerator, error) {
+			return NewArrayGenerator(func() *DArray {
+				arr := NewDArray(TypeInt)
+				_ = arr.Append(args[0])
+				return arr
+			}()), nil
+		},
+	)
+
+	factory, ok := LookupGeneratorFactory(name, []Type{TypeInt})
+	if !ok {
+		t.Fatalf("expected %q to be registered", name)
+	}
+
+	gen, err := factory(Datums{NewDInt(42)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gen.Close()
+
+	ok2, err := gen.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok2 {
+		t.Fatal("expected a row")
+	}
+	if got := int64(*gen.Values()[0].(*DInt)); got != 42 {
+		t.Fatalf("got %d, want 42", got)
+	}
+}
+
+// TestLookupGeneratorFactoryUnregistered verifies that a name with no
+// RegisterGenerator call reports ok=false rather than panicking or
+// matching some unrelated overload.
+func TestLookupGeneratorFactoryUnregistered(t *testing.T) {
+	if _, ok := LookupGeneratorFactory("no_such_generator", []Type{TypeInt}); ok {
+		t.Fatal("expected no match for an unregistered name")
+	}
+}
+
+// TestLookupGeneratorFactoryMultipleOverloads verifies that when a
+// name is registered with more than one overload, LookupGeneratorFactory
+// picks the one whose argument types actually match rather than the
+// first overload with the right argument count.
+func TestLookupGeneratorFactoryMultipleOverloads(t *testing.T) {
+	const name = "test_overloaded_generator"
+
+	intFactory := func(args Datums) (ValueGenerator, error) {
+		return NewArrayGenerator(func() *DArray {
+			arr := NewDArray(TypeInt)
+			_ = arr.Append(args[0])
+			return arr
+		}()), nil
+	}
+	stringFactory := func(args Datums) (ValueGenerator, error) {
+		return NewArrayGenerator(func() *DArray {
+			arr := NewDArray(TypeString)
+			_ = arr.Append(args[0])
+			return arr
+		}()), nil
+	}
+
+	RegisterGenerator(
+		name,
+		ArgTypes{{"x", TypeInt}},
+		fixedGeneratorColumns(ResultColumn{Name: "x", Typ: TypeInt}),
+		intFactory,
+	)
+	RegisterGenerator(
+		name,
+		ArgTypes{{"x", TypeString}},
+		fixedGeneratorColumns(ResultColumn{Name: "x", Typ: TypeString}),
+		stringFactory,
+	)
+
+	factory, ok := LookupGeneratorFactory(name, []Type{TypeString})
+	if !ok {
+		t.Fatalf("expected %q(string) to be registered", name)
+	}
+	gen, err := factory(Datums{NewDString("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gen.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer gen.Close()
+	if ok, err := gen.Next(); err != nil || !ok {
+		t.Fatalf("ok=%v err=%v", ok, err)
+	}
+	if got := string(*gen.Values()[0].(*DString)); got != "hello" {
+		t.Fatalf("got %q, want %q -- LookupGeneratorFactory picked the wrong overload", got, "hello")
+	}
+}